@@ -0,0 +1,20 @@
+// Package cache provides a small key/value abstraction used to avoid
+// redundant calls to upstream repository providers.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache is implemented by every cache backend the app can be configured to
+// use.
+type Cache interface {
+	// Get returns the value stored under key. ok is false when the key is
+	// absent or has expired.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores value under key for the given ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes key, if present.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"strconv"
+)
+
+// defaultPort is used when the PORT env var is unset, matching the port
+// convention used by the exercise's other Scalingo-style services.
+const defaultPort = 5000
+
+// config holds the app's runtime configuration, sourced from the
+// environment.
+type config struct {
+	Port int
+}
+
+// newConfig builds a config from the environment, defaulting PORT to
+// defaultPort when unset or invalid.
+func newConfig() (config, error) {
+	cfg := config{Port: defaultPort}
+	if port := os.Getenv("PORT"); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return config{}, err
+		}
+		cfg.Port = p
+	}
+	return cfg, nil
+}
@@ -1,52 +1,137 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
-	"sync"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Scalingo/go-handlers"
 	"github.com/Scalingo/go-utils/logger"
+	"github.com/Scalingo/sclng-backend-test-v1/cache"
+	"github.com/Scalingo/sclng-backend-test-v1/metrics"
+	"github.com/Scalingo/sclng-backend-test-v1/providers"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 )
 
-// RepositoryFromAPI struct according to the result of GithubAPI
-type RepositoryFromAPI struct {
-	FullName string `json:"full_name"`
-	Owner    struct {
-		Login string `json:"login"`
-	} `json:"owner"`
-	RepoName     string `json:"name"`
-	LanguagesURL string `json:"languages_url"`
+// RepositoriesResponse is the final response format, matching the JSON
+// format of the exercise.
+type RepositoriesResponse struct {
+	Repositories []providers.Repository `json:"repositories"`
 }
 
-// LanguageDetail holds the byte count for each programming language.
-type LanguageDetail struct {
-	Bytes int `json:"bytes"`
+// defaultPerPage is the page size requested from a provider when the caller
+// does not provide its own ?per_page= value.
+const defaultPerPage = 100
+
+// defaultCacheTTL is how long a cache entry stays valid, overridable via the
+// CACHE_TTL env var (e.g. "30s", "5m").
+var defaultCacheTTL = 60 * time.Second
+
+// defaultMaxConcurrency caps how many per-repository language fetches run at
+// once across a single /repos request, overridable via the MAX_CONCURRENCY
+// env var.
+var defaultMaxConcurrency = 20
+
+// mountedProviders holds every RepoProvider the app was configured to talk
+// to, keyed by Name(). It is populated once in main() from the
+// REPO_PROVIDERS env var.
+var mountedProviders map[string]providers.RepoProvider
+
+// newCacheFromEnv builds the Cache backend selected by the CACHE_BACKEND
+// env var ("memory" or "redis"), defaulting to the in-memory implementation
+// when unset.
+func newCacheFromEnv(log logrus.FieldLogger) (cache.Cache, error) {
+	switch os.Getenv("CACHE_BACKEND") {
+	case "redis":
+		log.Info("Using redis cache backend")
+		opts, err := redis.ParseURL(os.Getenv("REDIS_URL"))
+		if err != nil {
+			return nil, fmt.Errorf("parse REDIS_URL: %w", err)
+		}
+		return cache.NewRedis(redis.NewClient(opts)), nil
+	default:
+		log.Info("Using in-memory cache backend")
+		return cache.NewMemory(), nil
+	}
 }
 
-// Language is a map where the key is the language name and the value is LanguageDetail.
-type Language map[string]LanguageDetail
+// newMountedProviders builds the RepoProvider set listed in the
+// REPO_PROVIDERS env var (a comma-separated list, defaulting to "github"),
+// so multiple sources can be mounted and queried simultaneously.
+func newMountedProviders(log logrus.FieldLogger, githubToken string, c cache.Cache, cacheTTL time.Duration) map[string]providers.RepoProvider {
+	enabled := os.Getenv("REPO_PROVIDERS")
+	if enabled == "" {
+		enabled = "github"
+	}
 
-// Repository struct with data formatted
-type Repository struct {
-	FullName  string   `json:"full_name"`
-	Owner     string   `json:"owner"`
-	RepoName  string   `json:"repository"`
-	Languages Language `json:"languages"`
+	mounted := make(map[string]providers.RepoProvider)
+	for _, name := range strings.Split(enabled, ",") {
+		switch name {
+		case "github":
+			mounted[name] = providers.NewGithub(githubToken, c, cacheTTL)
+		case "gitlab":
+			mounted[name] = providers.NewGitLab(os.Getenv("GITLAB_BASE_URL"), os.Getenv("GITLAB_TOKEN"), c, cacheTTL)
+		case "gitea":
+			mounted[name] = providers.NewGitea(os.Getenv("GITEA_BASE_URL"), os.Getenv("GITEA_TOKEN"), c, cacheTTL)
+		case "sourcehut":
+			mounted[name] = providers.NewSourceHut(os.Getenv("SOURCEHUT_BASE_URL"), os.Getenv("SOURCEHUT_TOKEN"), c, cacheTTL)
+		default:
+			log.WithField("provider", name).Error("Unknown provider in REPO_PROVIDERS, skipping")
+		}
+	}
+	return mounted
 }
 
-// Struct for the final response to match the JSON format of the exercise
-type RepositoriesResponse struct {
-	Repositories []Repository `json:"repositories"`
+// parseRepositoryListOptions builds providers.ListOptions from the incoming
+// request's query string (?page=, ?per_page=, ?since=), falling back to
+// sane defaults when a param is absent or invalid.
+func parseRepositoryListOptions(r *http.Request) providers.ListOptions {
+	q := r.URL.Query()
+
+	opts := providers.ListOptions{Page: 1, PerPage: defaultPerPage}
+	if page, err := strconv.Atoi(q.Get("page")); err == nil && page > 0 {
+		opts.Page = page
+	}
+	if perPage, err := strconv.Atoi(q.Get("per_page")); err == nil && perPage > 0 {
+		opts.PerPage = perPage
+	}
+	if since, err := strconv.ParseInt(q.Get("since"), 10, 64); err == nil && since > 0 {
+		opts.Since = since
+	}
+	return opts
 }
 
-// Github API URL for the 100 last public repositories
-const githubAPIURL = "https://api.github.com/repositories?per_page=100&page=1"
+// selectProviders resolves the ?provider= query param (a comma-separated
+// list of provider names) against mountedProviders, defaulting to every
+// mounted provider when the param is absent.
+func selectProviders(r *http.Request) ([]providers.RepoProvider, error) {
+	requested := r.URL.Query().Get("provider")
+	if requested == "" {
+		selected := make([]providers.RepoProvider, 0, len(mountedProviders))
+		for _, p := range mountedProviders {
+			selected = append(selected, p)
+		}
+		return selected, nil
+	}
 
-// Github Token to avoid rate limits defined by default with GithubAPI
-var githubToken string
+	selected := make([]providers.RepoProvider, 0, len(mountedProviders))
+	for _, name := range strings.Split(requested, ",") {
+		p, ok := mountedProviders[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown provider %q", name)
+		}
+		selected = append(selected, p)
+	}
+	return selected, nil
+}
 
 func main() {
 	log := logger.Default()
@@ -58,22 +143,48 @@ func main() {
 	}
 
 	// Retrieve GITHUB_TOKEN var to authenticate to the Github API
-	githubToken = os.Getenv("GITHUB_TOKEN")
+	githubToken := os.Getenv("GITHUB_TOKEN")
 	if githubToken == "" {
 		log.WithError(fmt.Errorf("GITHUB_TOKEN environment variable not set in .env file")).Error("GITHUB_TOKEN env var not set")
 		os.Exit(1)
 	}
 
+	shutdownTracing, err := newTracerProviderFromEnv(log)
+	if err != nil {
+		log.WithError(err).Error("Fail to initialize tracing")
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
+	repoCache, err := newCacheFromEnv(log)
+	if err != nil {
+		log.WithError(err).Error("Fail to initialize cache backend")
+		os.Exit(1)
+	}
+	if ttl := os.Getenv("CACHE_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil {
+			defaultCacheTTL = d
+		}
+	}
+	if max, err := strconv.Atoi(os.Getenv("MAX_CONCURRENCY")); err == nil && max > 0 {
+		defaultMaxConcurrency = max
+	}
+
+	mountedProviders = newMountedProviders(log, githubToken, repoCache, defaultCacheTTL)
+
 	log.Info("Initializing routes")
 	router := handlers.NewRouter(log)
 	router.HandleFunc("/ping", pongHandler)
 	// Initialize web server and configure the following routes:
-	// GET /repos - Get all repositories
+	// GET /repos - Get all repositories, optionally across several
+	// ?provider=github,gitlab sources
 	router.HandleFunc("/repos", repositoriesHandler)
 	// GET /repos/lang/{lang} - Get all repositories containing {lang} as programming languages
 	router.HandleFunc("/repos/lang/{lang}", repositoriesHandler)
 	// GET /repos/owner/{owner} - Get all repositories owned by {owner}
 	router.HandleFunc("/repos/owner/{owner}", repositoriesHandler)
+	// GET /metrics - Prometheus metrics
+	router.HandleFunc("/metrics", metricsHandler)
 
 	log = log.WithField("port", cfg.Port)
 	log.Info("Listening...")
@@ -84,6 +195,13 @@ func main() {
 	}
 }
 
+// metricsHandler serves /metrics by delegating to metrics.Handler, adapting
+// it to go-handlers' func(w, r, params) error signature.
+func metricsHandler(w http.ResponseWriter, r *http.Request, _ map[string]string) error {
+	metrics.Handler().ServeHTTP(w, r)
+	return nil
+}
+
 func pongHandler(w http.ResponseWriter, r *http.Request, _ map[string]string) error {
 	log := logger.Get(r.Context())
 	w.Header().Add("Content-Type", "application/json")
@@ -96,114 +214,161 @@ func pongHandler(w http.ResponseWriter, r *http.Request, _ map[string]string) er
 	return nil
 }
 
-// repositoriesHandler handles request to GithubAPI and formats data for a specific request
-func repositoriesHandler(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+// responseFormat selects how /repos encodes its response.
+type responseFormat string
 
-	log := logger.Get(r.Context())
-	w.Header().Add("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+const (
+	formatJSON   responseFormat = "json"
+	formatNDJSON responseFormat = "ndjson"
+	formatSSE    responseFormat = "sse"
+)
 
-	// Retrieve filter if exists
-	var filterType, filterValue string
-	for fType, fValue := range params {
-		filterType = fType
-		filterValue = fValue
+// parseResponseFormat resolves the desired responseFormat from ?format=, or
+// failing that, the Accept header, defaulting to the original buffered JSON
+// format.
+func parseResponseFormat(r *http.Request) responseFormat {
+	switch r.URL.Query().Get("format") {
+	case "ndjson":
+		return formatNDJSON
+	case "sse":
+		return formatSSE
 	}
 
-	// Create the GET Request to GithubAPI to retrieve repositories
-	req, err := http.NewRequest("GET", githubAPIURL, nil)
-	if err != nil {
-		log.WithError(err).Error("Fail to create GET Request to fetch repositories")
+	switch r.Header.Get("Accept") {
+	case "application/x-ndjson":
+		return formatNDJSON
+	case "text/event-stream":
+		return formatSSE
 	}
-	// Set Auth with the github token defined in .env file
-	req.Header.Set("Authorization", "token "+githubToken)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.WithError(err).Error("Fail to make GET Request to fetch repositories")
-	}
-	defer resp.Body.Close()
+	return formatJSON
+}
 
-	if resp.StatusCode != http.StatusOK {
-		log.WithError(fmt.Errorf(resp.Status)).Error("HTTP Status non-OK after fetching repositories")
+// routeLabel returns the registered route pattern for r (e.g.
+// "/repos/lang/{lang}"), falling back to "unknown" when none is matched.
+// Used as a Prometheus label instead of r.URL.Path, which embeds arbitrary
+// caller input and would otherwise give the metric unbounded cardinality.
+func routeLabel(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "unknown"
 	}
-
-	// Parse response
-	var repositories []RepositoryFromAPI
-	if err := json.NewDecoder(resp.Body).Decode(&repositories); err != nil {
-		log.Fatalf("Error decoding response from GithubAPI: %v", err)
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return "unknown"
 	}
+	return tpl
+}
 
-	// Setup to fetch languages data concurrently
-	var wg sync.WaitGroup
-	reposResponse := make([]Repository, 0, len(repositories))
-	resultsCh := make(chan Repository) // Channel to collect results
-	errCh := make(chan error)          // Channel to collect errors
-
-	for _, repo := range repositories {
-		wg.Add(1)
-		go func(repo RepositoryFromAPI) {
-			defer wg.Done()
-
-			// Temporary map to hold raw languages data
-			languages := make(map[string]int)
-			languagesURL := repo.LanguagesURL
+// repositoriesHandler fans requests out to every selected RepoProvider,
+// then formats and merges their data for a specific request. The buffered
+// JSON format waits for every provider and every language lookup before
+// writing anything; ndjson and sse stream each Repository out as soon as
+// its goroutine completes.
+func repositoriesHandler(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	start := time.Now()
+	defer func() {
+		metrics.RequestDuration.WithLabelValues(routeLabel(r)).Observe(time.Since(start).Seconds())
+	}()
 
-			// Create the GET Request to GithubAPI to retrieve languages data
-			req, err := http.NewRequest("GET", languagesURL, nil)
-			if err != nil {
-				errCh <- fmt.Errorf("failed to create request for languages for %s: %w", repo.FullName, err)
-				return
-			}
+	log := logger.Get(r.Context())
+	ctx := r.Context()
 
-			// Set Auth with the github token defined in .env file
-			req.Header.Set("Authorization", "token "+githubToken)
+	query, err := parseQuery(r, params)
+	if err != nil {
+		log.WithError(err).Error("Invalid query")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
 
-			resp, err := client.Do(req)
+	selected, err := selectProviders(r)
+	if err != nil {
+		log.WithError(err).Error("Fail to resolve requested providers")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	listOpts := parseRepositoryListOptions(r)
+
+	// Fan out per-provider listing, and per-repository language fetches,
+	// through an errgroup so a hard failure (e.g. a provider's listing call
+	// failing) cancels every other in-flight goroutine instead of leaking
+	// them. Language fetches are additionally gated by sem, capping how many
+	// run at once, and failures there are soft: they produce a Repository
+	// with Error set rather than aborting the whole response.
+	g, gctx := errgroup.WithContext(ctx)
+	sem := make(chan struct{}, defaultMaxConcurrency)
+	resultsCh := make(chan providers.Repository)
+	errCh := make(chan error, len(selected))
+
+	for _, provider := range selected {
+		provider := provider
+		g.Go(func() error {
+			repos, err := provider.ListRepositories(gctx, listOpts)
 			if err != nil {
-				errCh <- fmt.Errorf("error fetching languages for %s: %w", repo.FullName, err)
-				return
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != http.StatusOK {
-				errCh <- fmt.Errorf("error fetching languages for %s: %s", repo.FullName, resp.Status)
-				return
-			}
-
-			if err := json.NewDecoder(resp.Body).Decode(&languages); err != nil {
-				errCh <- fmt.Errorf("error decoding languages for %s: %w", repo.FullName, err)
-				return
+				err = fmt.Errorf("list repositories from %s: %w", provider.Name(), err)
+				errCh <- err
+				return err
 			}
 
-			// Convert the raw map to the desired structure
-			languagesDetail := make(Language)
-			for lang, bytes := range languages {
-				languagesDetail[lang] = LanguageDetail{Bytes: bytes} // Wrap byte count in LanguageDetail
+			for _, repo := range repos {
+				repo := repo
+
+				select {
+				case sem <- struct{}{}:
+				case <-gctx.Done():
+					return gctx.Err()
+				}
+
+				g.Go(func() error {
+					defer func() { <-sem }()
+
+					metrics.InFlightLanguageFetches.Inc()
+					languages, err := provider.FetchLanguages(gctx, repo)
+					metrics.InFlightLanguageFetches.Dec()
+					if err != nil {
+						log.WithError(err).WithField("repository", repo.FullName).Error("Fail to fetch languages, returning repository with its Error field set")
+						repo.Error = err.Error()
+					} else {
+						repo.Languages = languages
+					}
+
+					select {
+					case resultsCh <- repo:
+						return nil
+					case <-gctx.Done():
+						return gctx.Err()
+					}
+				})
 			}
-
-			// Create a repository object
-			repoResponse := Repository{
-				FullName:  repo.FullName,
-				Owner:     repo.Owner.Login,
-				RepoName:  repo.RepoName,
-				Languages: languagesDetail,
-			}
-
-			// Send the result to the channel
-			resultsCh <- repoResponse
-		}(repo)
+			return nil
+		})
 	}
 
-	// Wait for all goroutines to finish
+	// Wait for every goroutine to finish, then close the channels the
+	// writers below range over.
 	go func() {
-		wg.Wait()
+		g.Wait() //nolint:errcheck // failures were already reported on errCh
 		close(resultsCh)
 		close(errCh)
 	}()
 
-	// Collect results and handle errors
+	switch parseResponseFormat(r) {
+	case formatNDJSON:
+		return streamNDJSON(w, log, resultsCh, errCh, query)
+	case formatSSE:
+		return streamSSE(w, log, resultsCh, errCh, query)
+	default:
+		return writeBufferedJSON(w, log, resultsCh, errCh, query)
+	}
+}
+
+// writeBufferedJSON collects every result before filtering, sorting, and
+// encoding the exercise's original JSON response format.
+func writeBufferedJSON(w http.ResponseWriter, log logrus.FieldLogger, resultsCh <-chan providers.Repository, errCh <-chan error, query Query) error {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	reposResponse := make([]providers.Repository, 0)
 	for {
 		select {
 		case repo, ok := <-resultsCh:
@@ -218,7 +383,7 @@ func repositoriesHandler(w http.ResponseWriter, r *http.Request, params map[stri
 				// If error channel is closed, set it to nil
 				errCh = nil
 			} else {
-				log.WithError(err).Error("Error occurred during language fetching")
+				log.WithError(err).Error("Error occurred while fetching repositories")
 				return err // Return the first error encountered
 			}
 		}
@@ -228,43 +393,106 @@ func repositoriesHandler(w http.ResponseWriter, r *http.Request, params map[stri
 		}
 	}
 
-	// If a filter was provided, then filter the results
-	if filterType != "" {
-		reposResponse = filterByType(filterType, filterValue, reposResponse)
-	}
+	reposResponse = query.apply(reposResponse)
 
-	// Create final response format to match the JSON format of the exercise
 	response := RepositoriesResponse{
 		Repositories: reposResponse,
 	}
-
-	err = json.NewEncoder(w).Encode(response)
-	if err != nil {
+	if err := json.NewEncoder(w).Encode(response); err != nil {
 		log.WithError(err).Error("Fail to encode JSON")
 	}
-
 	return nil
 }
 
-// filterByType filters repositories by language or owner
-// You can add more filter types here by adding new case in switch statements
-func filterByType(filterType, filterValue string, repos []Repository) []Repository {
-	var filteredRepos []Repository
-	switch filterType {
-	case "lang":
-		for _, repo := range repos {
-			if _, exists := repo.Languages[filterValue]; exists {
-				filteredRepos = append(filteredRepos, repo)
+// streamNDJSON writes one JSON-encoded Repository per line as soon as it
+// arrives on resultsCh, flushing after every write so callers see data as
+// it becomes available instead of waiting for the whole fan-out to finish.
+// Only query's predicates apply here: sorting and limiting need the full
+// result set, so they are left to the buffered JSON format.
+func streamNDJSON(w http.ResponseWriter, log logrus.FieldLogger, resultsCh <-chan providers.Repository, errCh <-chan error, query Query) error {
+	w.Header().Add("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	for {
+		select {
+		case repo, ok := <-resultsCh:
+			if !ok {
+				resultsCh = nil
+				continue
+			}
+			if !query.matches(repo) {
+				continue
 			}
+			if err := encoder.Encode(repo); err != nil {
+				log.WithError(err).Error("Fail to encode NDJSON repository")
+				return nil
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+				continue
+			}
+			log.WithError(err).Error("Error occurred while fetching repositories")
+			return err
 		}
-	case "owner":
-		for _, repo := range repos {
-			if repo.Owner == filterValue {
-				filteredRepos = append(filteredRepos, repo)
+		if resultsCh == nil && errCh == nil {
+			break
+		}
+	}
+	return nil
+}
+
+// streamSSE writes one "repository" Server-Sent Event per result, in the
+// same fire-as-soon-as-ready fashion as streamNDJSON. Only query's
+// predicates apply here, for the same reason as streamNDJSON.
+func streamSSE(w http.ResponseWriter, log logrus.FieldLogger, resultsCh <-chan providers.Repository, errCh <-chan error, query Query) error {
+	w.Header().Add("Content-Type", "text/event-stream")
+	w.Header().Add("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+
+	for {
+		select {
+		case repo, ok := <-resultsCh:
+			if !ok {
+				resultsCh = nil
+				continue
+			}
+			if !query.matches(repo) {
+				continue
 			}
+			data, err := json.Marshal(repo)
+			if err != nil {
+				log.WithError(err).Error("Fail to encode SSE repository")
+				continue
+			}
+			fmt.Fprintf(w, "event: repository\ndata: %s\n\n", data)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+				continue
+			}
+			log.WithError(err).Error("Error occurred while fetching repositories")
+			return err
+		}
+		if resultsCh == nil && errCh == nil {
+			break
 		}
-	default:
-		return repos
 	}
-	return filteredRepos
+
+	fmt.Fprint(w, "event: done\ndata: {}\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+	return nil
 }
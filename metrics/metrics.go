@@ -0,0 +1,56 @@
+// Package metrics exposes the Prometheus collectors the app uses to observe
+// its own behavior, and the /metrics HTTP handler that serves them.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// ProviderCalls counts outbound calls made to a repository provider
+	// API, by provider, endpoint, and resulting HTTP status.
+	ProviderCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sclng_provider_calls_total",
+		Help: "Number of outbound calls made to a repository provider API.",
+	}, []string{"provider", "endpoint", "status"})
+
+	// GithubRateLimitRemaining tracks the last X-RateLimit-Remaining value
+	// Github reported.
+	GithubRateLimitRemaining = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "sclng_github_rate_limit_remaining",
+		Help: "Remaining Github API calls before the rate limit resets.",
+	})
+
+	// RequestDuration observes how long a request takes to serve, by route.
+	RequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "sclng_request_duration_seconds",
+		Help: "Time spent serving an HTTP request.",
+	}, []string{"route"})
+
+	// CacheHits and CacheMisses count cache lookups, by cache key prefix
+	// (e.g. "github:repos", "github:languages").
+	CacheHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sclng_cache_hits_total",
+		Help: "Number of cache lookups that found a usable value.",
+	}, []string{"kind"})
+	CacheMisses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sclng_cache_misses_total",
+		Help: "Number of cache lookups that found nothing usable.",
+	}, []string{"kind"})
+
+	// InFlightLanguageFetches tracks how many per-repository language
+	// fetches are currently running, across every provider.
+	InFlightLanguageFetches = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "sclng_in_flight_language_fetches",
+		Help: "Number of language-fetch goroutines currently in flight.",
+	})
+)
+
+// Handler returns the http.Handler that serves /metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,43 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Scalingo/sclng-backend-test-v1/cache"
+	"github.com/Scalingo/sclng-backend-test-v1/metrics"
+)
+
+// cacheGetJSON looks key up in c and JSON-decodes it into dest, reporting
+// the lookup to metrics.CacheHits/metrics.CacheMisses. It reports whether a
+// usable value was found.
+func cacheGetJSON(ctx context.Context, c cache.Cache, key string, dest interface{}) bool {
+	value, ok, err := c.Get(ctx, key)
+	if err != nil || !ok || json.Unmarshal(value, dest) != nil {
+		metrics.CacheMisses.WithLabelValues(cacheKind(key)).Inc()
+		return false
+	}
+	metrics.CacheHits.WithLabelValues(cacheKind(key)).Inc()
+	return true
+}
+
+// cacheSetJSON JSON-encodes v and stores it under key in c for ttl.
+func cacheSetJSON(ctx context.Context, c cache.Cache, key string, v interface{}, ttl time.Duration) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	_ = c.Set(ctx, key, data, ttl)
+}
+
+// cacheKind extracts the "kind" label metrics.CacheHits/CacheMisses use
+// from a cache key, e.g. "github:languages:foo/bar" -> "github:languages".
+func cacheKind(key string) string {
+	parts := strings.SplitN(key, ":", 3)
+	if len(parts) < 2 {
+		return key
+	}
+	return parts[0] + ":" + parts[1]
+}
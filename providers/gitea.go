@@ -0,0 +1,145 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Scalingo/sclng-backend-test-v1/cache"
+	"golang.org/x/sync/singleflight"
+)
+
+// giteaRepo matches the subset of the Gitea "Repository" resource we care
+// about.
+type giteaRepo struct {
+	FullName string `json:"full_name"`
+	Name     string `json:"name"`
+	Owner    struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+// Gitea lists repositories and language stats from a self-hosted Gitea
+// instance's v1 REST API, caching results and collapsing concurrent misses
+// with a singleflight.Group so bursts of requests only trigger one upstream
+// call.
+type Gitea struct {
+	baseURL  string
+	token    string
+	client   *http.Client
+	cache    cache.Cache
+	cacheTTL time.Duration
+
+	fetchGroup singleflight.Group
+}
+
+// NewGitea returns a Gitea provider talking to baseURL, authenticated with
+// token, caching results in c for ttl.
+func NewGitea(baseURL, token string, c cache.Cache, ttl time.Duration) *Gitea {
+	return &Gitea{baseURL: baseURL, token: token, client: &http.Client{}, cache: c, cacheTTL: ttl}
+}
+
+// Name implements RepoProvider.
+func (gt *Gitea) Name() string { return "gitea" }
+
+// ListRepositories implements RepoProvider using Gitea's limit/page query
+// params, going through gt.cache first and collapsing concurrent misses via
+// gt.fetchGroup.
+func (gt *Gitea) ListRepositories(ctx context.Context, opts ListOptions) ([]Repository, error) {
+	cacheKey := fmt.Sprintf("gitea:repos:page=%d:per_page=%d", opts.Page, opts.PerPage)
+
+	var repos []Repository
+	if cacheGetJSON(ctx, gt.cache, cacheKey, &repos) {
+		return repos, nil
+	}
+
+	v, err, _ := gt.fetchGroup.Do(cacheKey, func() (interface{}, error) {
+		reqURL := fmt.Sprintf("%s/api/v1/repos/search?page=%d&limit=%d", gt.baseURL, opts.Page, opts.PerPage)
+
+		var result struct {
+			Data []giteaRepo `json:"data"`
+		}
+		if err := gt.get(ctx, "list_repositories", reqURL, &result); err != nil {
+			return nil, fmt.Errorf("fetch repositories from gitea: %w", err)
+		}
+
+		out := make([]Repository, 0, len(result.Data))
+		for _, repo := range result.Data {
+			out = append(out, Repository{
+				Source:   gt.Name(),
+				FullName: repo.FullName,
+				Owner:    repo.Owner.Login,
+				RepoName: repo.Name,
+			})
+		}
+		cacheSetJSON(ctx, gt.cache, cacheKey, out, gt.cacheTTL)
+		return out, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]Repository), nil
+}
+
+// FetchLanguages implements RepoProvider, going through gt.cache first and
+// collapsing concurrent misses for the same repo via gt.fetchGroup. Gitea's
+// languages endpoint returns byte counts directly, same shape as Github's.
+func (gt *Gitea) FetchLanguages(ctx context.Context, repo Repository) (Language, error) {
+	cacheKey := "gitea:languages:" + repo.FullName
+
+	var languages Language
+	if cacheGetJSON(ctx, gt.cache, cacheKey, &languages) {
+		return languages, nil
+	}
+
+	v, err, _ := gt.fetchGroup.Do(cacheKey, func() (interface{}, error) {
+		reqURL := fmt.Sprintf("%s/api/v1/repos/%s/languages", gt.baseURL, repo.FullName)
+
+		var rawLanguages map[string]int
+		if err := gt.get(ctx, "languages", reqURL, &rawLanguages); err != nil {
+			return nil, fmt.Errorf("fetch languages for %s: %w", repo.FullName, err)
+		}
+
+		languages := make(Language, len(rawLanguages))
+		for lang, bytes := range rawLanguages {
+			languages[lang] = LanguageDetail{Bytes: bytes}
+		}
+		cacheSetJSON(ctx, gt.cache, cacheKey, languages, gt.cacheTTL)
+		return languages, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(Language), nil
+}
+
+func (gt *Gitea) get(ctx context.Context, endpoint, reqURL string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "token "+gt.token)
+
+	spanCtx, span := tracer.Start(ctx, "gitea."+endpoint)
+	req = req.WithContext(spanCtx)
+	resp, err := gt.client.Do(req)
+	span.End()
+
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+	recordProviderCall(gt.Name(), endpoint, statusCode)
+
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
@@ -0,0 +1,225 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Scalingo/go-utils/logger"
+	"github.com/Scalingo/sclng-backend-test-v1/cache"
+	"github.com/Scalingo/sclng-backend-test-v1/metrics"
+	"github.com/google/go-github/v58/github"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+)
+
+// Github lists repositories and language stats from the Github REST API via
+// go-github, caching results and collapsing concurrent misses with a
+// singleflight.Group so bursts of requests only trigger one upstream call.
+type Github struct {
+	client   *github.Client
+	cache    cache.Cache
+	cacheTTL time.Duration
+
+	fetchGroup singleflight.Group
+}
+
+// githubLanguagesEntry is what we store in g.cache for a repo's language
+// breakdown: the ETag travels with the data under the same TTL, so the two
+// always expire together and a conditional request is never sent for data
+// we no longer have on hand.
+type githubLanguagesEntry struct {
+	Languages Language `json:"languages"`
+	ETag      string   `json:"etag"`
+}
+
+// NewGithub returns a Github provider authenticated with token, caching
+// results in c for ttl.
+func NewGithub(token string, c cache.Cache, ttl time.Duration) *Github {
+	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	return &Github{
+		client:   github.NewClient(oauth2.NewClient(context.Background(), tokenSource)),
+		cache:    c,
+		cacheTTL: ttl,
+	}
+}
+
+// Name implements RepoProvider.
+func (g *Github) Name() string { return "github" }
+
+// ListRepositories implements RepoProvider, going through g.cache first and
+// walking the since-cursor pagination until it has gathered the requested
+// page rather than stopping at the first 100 results.
+func (g *Github) ListRepositories(ctx context.Context, opts ListOptions) ([]Repository, error) {
+	cacheKey := fmt.Sprintf("github:repos:page=%d:per_page=%d:since=%d", opts.Page, opts.PerPage, opts.Since)
+
+	var repos []Repository
+	if cacheGetJSON(ctx, g.cache, cacheKey, &repos) {
+		return repos, nil
+	}
+
+	v, err, _ := g.fetchGroup.Do(cacheKey, func() (interface{}, error) {
+		repos, err := g.listAll(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		cacheSetJSON(ctx, g.cache, cacheKey, repos, g.cacheTTL)
+		return repos, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]Repository), nil
+}
+
+func (g *Github) listAll(ctx context.Context, opts ListOptions) ([]Repository, error) {
+	log := logger.Get(ctx)
+	listOpts := &github.RepositoryListAllOptions{Since: opts.Since}
+
+	var all []*github.Repository
+	for len(all) < opts.Page*opts.PerPage {
+		spanCtx, span := tracer.Start(ctx, "github.ListRepositories")
+		repos, resp, err := g.client.Repositories.ListAll(spanCtx, listOpts)
+		span.End()
+
+		statusCode := 0
+		if resp != nil {
+			statusCode = resp.StatusCode
+			metrics.GithubRateLimitRemaining.Set(float64(resp.Rate.Remaining))
+		}
+		recordProviderCall(g.Name(), "list_repositories", statusCode)
+
+		if err != nil {
+			return nil, fmt.Errorf("fetch repositories from github: %w", err)
+		}
+		if err := g.waitForRateLimit(ctx, log, resp); err != nil {
+			return nil, err
+		}
+		if len(repos) == 0 {
+			break
+		}
+
+		all = append(all, repos...)
+		listOpts.Since = repos[len(repos)-1].GetID()
+	}
+
+	start := (opts.Page - 1) * opts.PerPage
+	if start >= len(all) {
+		return nil, nil
+	}
+	end := start + opts.PerPage
+	if end > len(all) {
+		end = len(all)
+	}
+
+	out := make([]Repository, 0, end-start)
+	for _, repo := range all[start:end] {
+		out = append(out, Repository{
+			Source:   g.Name(),
+			FullName: repo.GetFullName(),
+			Owner:    repo.GetOwner().GetLogin(),
+			RepoName: repo.GetName(),
+		})
+	}
+	return out, nil
+}
+
+// FetchLanguages implements RepoProvider, going through g.cache first and
+// collapsing concurrent misses for the same repo via g.fetchGroup.
+func (g *Github) FetchLanguages(ctx context.Context, repo Repository) (Language, error) {
+	cacheKey := "github:languages:" + repo.FullName
+
+	var cached githubLanguagesEntry
+	if cacheGetJSON(ctx, g.cache, cacheKey, &cached) {
+		return cached.Languages, nil
+	}
+
+	v, err, _ := g.fetchGroup.Do(cacheKey, func() (interface{}, error) {
+		return g.getLanguages(ctx, repo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(Language), nil
+}
+
+// getLanguages performs the actual Github call for repo's language
+// breakdown. It sends an If-None-Match header when a previous ETag was
+// cached, and on a 304 reuses the data that ETag was cached with. Storing
+// the ETag inside the same g.cache entry as the data, under the same TTL,
+// keeps the two from drifting apart: once the entry expires, both the data
+// and the ETag are gone together, so we never send a conditional request
+// for a response we no longer have on hand.
+func (g *Github) getLanguages(ctx context.Context, repo Repository) (Language, error) {
+	log := logger.Get(ctx)
+	url := fmt.Sprintf("https://api.github.com/repos/%s/languages", repo.FullName)
+	cacheKey := "github:languages:" + repo.FullName
+
+	var cached githubLanguagesEntry
+	haveCached := cacheGetJSON(ctx, g.cache, cacheKey, &cached)
+
+	req, err := g.client.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request for languages for %s: %w", repo.FullName, err)
+	}
+	if haveCached && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	spanCtx, span := tracer.Start(ctx, "github.FetchLanguages")
+	var rawLanguages map[string]int
+	resp, err := g.client.Do(spanCtx, req, &rawLanguages)
+	span.End()
+
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+		metrics.GithubRateLimitRemaining.Set(float64(resp.Rate.Remaining))
+	}
+	recordProviderCall(g.Name(), "languages", statusCode)
+
+	if resp != nil && resp.StatusCode == http.StatusNotModified {
+		// go-github's CheckResponse treats a 304 as a non-nil err; since we
+		// only ever send If-None-Match alongside a cached entry, that
+		// entry is the data this 304 refers to.
+		return cached.Languages, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch languages for %s: %w", repo.FullName, err)
+	}
+	if err := g.waitForRateLimit(ctx, log, resp); err != nil {
+		return nil, err
+	}
+
+	languagesDetail := make(Language, len(rawLanguages))
+	for lang, bytes := range rawLanguages {
+		languagesDetail[lang] = LanguageDetail{Bytes: bytes}
+	}
+
+	cacheSetJSON(ctx, g.cache, cacheKey, githubLanguagesEntry{Languages: languagesDetail, ETag: resp.Header.Get("ETag")}, g.cacheTTL)
+	return languagesDetail, nil
+}
+
+// waitForRateLimit inspects the rate-limit headers Github attached to resp
+// and sleeps until the quota resets whenever it has been exhausted, instead
+// of letting the next call fail or silently returning partial results.
+func (g *Github) waitForRateLimit(ctx context.Context, log logrus.FieldLogger, resp *github.Response) error {
+	if resp == nil || resp.Rate.Remaining > 0 {
+		return nil
+	}
+
+	wait := time.Until(resp.Rate.Reset.Time)
+	if wait <= 0 {
+		return nil
+	}
+
+	log.WithField("reset_at", resp.Rate.Reset.Time).Info("Github rate limit exhausted, backing off")
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,23 @@
+package providers
+
+import (
+	"strconv"
+
+	"github.com/Scalingo/sclng-backend-test-v1/metrics"
+	"go.opentelemetry.io/otel"
+)
+
+// tracer is shared by every RepoProvider implementation for the spans
+// wrapping their outbound calls.
+var tracer = otel.Tracer("sclng-backend-test-v1/providers")
+
+// recordProviderCall reports an outbound provider API call to
+// metrics.ProviderCalls. statusCode is 0 when the call never got a
+// response, e.g. a network error.
+func recordProviderCall(provider, endpoint string, statusCode int) {
+	status := "error"
+	if statusCode != 0 {
+		status = strconv.Itoa(statusCode)
+	}
+	metrics.ProviderCalls.WithLabelValues(provider, endpoint, status).Inc()
+}
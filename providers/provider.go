@@ -0,0 +1,47 @@
+// Package providers abstracts over the different repository hosting
+// services the app can list repositories from.
+package providers
+
+import "context"
+
+// LanguageDetail holds the byte count for each programming language.
+type LanguageDetail struct {
+	Bytes int `json:"bytes"`
+}
+
+// Language is a map where the key is the language name and the value is LanguageDetail.
+type Language map[string]LanguageDetail
+
+// Repository is the normalized representation of a repository, regardless
+// of which RepoProvider it came from.
+type Repository struct {
+	Source    string   `json:"source"`
+	FullName  string   `json:"full_name"`
+	Owner     string   `json:"owner"`
+	RepoName  string   `json:"repository"`
+	Languages Language `json:"languages,omitempty"`
+	// Error is set instead of Languages when the language lookup for this
+	// repository failed, so one bad repo doesn't keep the rest of the
+	// response from being served.
+	Error string `json:"error,omitempty"`
+}
+
+// ListOptions carries the pagination knobs every RepoProvider understands,
+// translated from each backend's native paging scheme.
+type ListOptions struct {
+	Page    int
+	PerPage int
+	Since   int64
+}
+
+// RepoProvider is implemented by every repository source the app can be
+// configured to list from.
+type RepoProvider interface {
+	// Name identifies the provider. It is used to tag Repository.Source
+	// and to select the provider via /repos?provider=.
+	Name() string
+	// ListRepositories lists repositories according to opts.
+	ListRepositories(ctx context.Context, opts ListOptions) ([]Repository, error)
+	// FetchLanguages retrieves the language breakdown for repo.
+	FetchLanguages(ctx context.Context, repo Repository) (Language, error)
+}
@@ -0,0 +1,156 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Scalingo/sclng-backend-test-v1/cache"
+	"golang.org/x/sync/singleflight"
+)
+
+// defaultSourceHutBaseURL is used when no self-hosted instance is
+// configured.
+const defaultSourceHutBaseURL = "https://git.sr.ht"
+
+// sourcehutRepo matches the subset of the SourceHut "repository" resource
+// we care about.
+type sourcehutRepo struct {
+	Name  string `json:"name"`
+	Owner struct {
+		CanonicalName string `json:"canonical_name"`
+	} `json:"owner"`
+}
+
+// sourcehutPage is a single page of SourceHut's cursor-paginated repository
+// listing: Next is empty once the last page has been reached.
+type sourcehutPage struct {
+	Results []sourcehutRepo `json:"results"`
+	Next    string          `json:"next"`
+}
+
+// SourceHut lists repositories from a SourceHut (git.sr.ht-compatible)
+// instance, caching the repository listing and collapsing concurrent
+// misses with a singleflight.Group so bursts of requests only trigger one
+// upstream call.
+type SourceHut struct {
+	baseURL  string
+	token    string
+	client   *http.Client
+	cache    cache.Cache
+	cacheTTL time.Duration
+
+	fetchGroup singleflight.Group
+}
+
+// NewSourceHut returns a SourceHut provider talking to baseURL (defaulting
+// to git.sr.ht when empty), authenticated with token, caching results in c
+// for ttl.
+func NewSourceHut(baseURL, token string, c cache.Cache, ttl time.Duration) *SourceHut {
+	if baseURL == "" {
+		baseURL = defaultSourceHutBaseURL
+	}
+	return &SourceHut{baseURL: baseURL, token: token, client: &http.Client{}, cache: c, cacheTTL: ttl}
+}
+
+// Name implements RepoProvider.
+func (sh *SourceHut) Name() string { return "sourcehut" }
+
+// ListRepositories implements RepoProvider, following the cursor-by-`next`
+// pagination pattern documented by the SourceHut API until either the
+// requested page has been collected or the listing runs dry. It goes
+// through sh.cache first and collapses concurrent misses via sh.fetchGroup.
+func (sh *SourceHut) ListRepositories(ctx context.Context, opts ListOptions) ([]Repository, error) {
+	cacheKey := fmt.Sprintf("sourcehut:repos:page=%d:per_page=%d", opts.Page, opts.PerPage)
+
+	var repos []Repository
+	if cacheGetJSON(ctx, sh.cache, cacheKey, &repos) {
+		return repos, nil
+	}
+
+	v, err, _ := sh.fetchGroup.Do(cacheKey, func() (interface{}, error) {
+		var all []sourcehutRepo
+		cursor := ""
+		for len(all) < opts.Page*opts.PerPage {
+			reqURL := fmt.Sprintf("%s/api/repos", sh.baseURL)
+			if cursor != "" {
+				reqURL += "?cursor=" + cursor
+			}
+
+			var page sourcehutPage
+			if err := sh.get(ctx, "list_repositories", reqURL, &page); err != nil {
+				return nil, fmt.Errorf("fetch repositories from sourcehut: %w", err)
+			}
+
+			all = append(all, page.Results...)
+			if page.Next == "" {
+				break
+			}
+			cursor = page.Next
+		}
+
+		start := (opts.Page - 1) * opts.PerPage
+		if start >= len(all) {
+			cacheSetJSON(ctx, sh.cache, cacheKey, []Repository{}, sh.cacheTTL)
+			return []Repository{}, nil
+		}
+		end := start + opts.PerPage
+		if end > len(all) {
+			end = len(all)
+		}
+
+		out := make([]Repository, 0, end-start)
+		for _, repo := range all[start:end] {
+			out = append(out, Repository{
+				Source:   sh.Name(),
+				FullName: repo.Owner.CanonicalName + "/" + repo.Name,
+				Owner:    repo.Owner.CanonicalName,
+				RepoName: repo.Name,
+			})
+		}
+		cacheSetJSON(ctx, sh.cache, cacheKey, out, sh.cacheTTL)
+		return out, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]Repository), nil
+}
+
+// FetchLanguages implements RepoProvider. SourceHut does not expose a
+// per-language byte-count endpoint, so this returns an empty breakdown
+// rather than fabricating one.
+func (sh *SourceHut) FetchLanguages(ctx context.Context, repo Repository) (Language, error) {
+	return Language{}, nil
+}
+
+func (sh *SourceHut) get(ctx context.Context, endpoint, reqURL string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+sh.token)
+
+	spanCtx, span := tracer.Start(ctx, "sourcehut."+endpoint)
+	req = req.WithContext(spanCtx)
+	resp, err := sh.client.Do(req)
+	span.End()
+
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+	recordProviderCall(sh.Name(), endpoint, statusCode)
+
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Scalingo/sclng-backend-test-v1/providers"
+)
+
+// Query is the parsed, validated set of filter/sort options /repos accepts.
+// It makes /repos/lang/{lang} and /repos/owner/{owner} special cases of the
+// same general endpoint: their path params just seed Query.Lang/Query.Owner.
+type Query struct {
+	Lang         string
+	Owner        string
+	NameRegex    *regexp.Regexp
+	MinBytes     int
+	MinLanguages int
+	SortBy       string // "bytes_total" or "lang_bytes", empty means unsorted
+	SortDesc     bool
+	Limit        int
+}
+
+// parseQuery builds a Query from the router's path params (populated by the
+// /repos/lang/{lang} and /repos/owner/{owner} routes) and the request's
+// query string, validating everything up front so repositoriesHandler can
+// assume a well-formed Query.
+func parseQuery(r *http.Request, params map[string]string) (Query, error) {
+	q := r.URL.Query()
+
+	query := Query{
+		Lang:  firstNonEmpty(params["lang"], q.Get("lang")),
+		Owner: firstNonEmpty(params["owner"], q.Get("owner")),
+	}
+
+	if pattern := q.Get("name"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return Query{}, fmt.Errorf("invalid name regex %q: %w", pattern, err)
+		}
+		query.NameRegex = re
+	}
+
+	if v := q.Get("min_bytes"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return Query{}, fmt.Errorf("invalid min_bytes %q", v)
+		}
+		query.MinBytes = n
+	}
+
+	if v := q.Get("min_languages"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return Query{}, fmt.Errorf("invalid min_languages %q", v)
+		}
+		query.MinLanguages = n
+	}
+
+	if v := q.Get("sort"); v != "" {
+		query.SortDesc = strings.HasPrefix(v, "-")
+		query.SortBy = strings.TrimPrefix(v, "-")
+		switch query.SortBy {
+		case "bytes_total", "lang_bytes":
+		default:
+			return Query{}, fmt.Errorf("invalid sort %q, expected bytes_total or lang_bytes (optionally prefixed with -)", v)
+		}
+		if query.SortBy == "lang_bytes" && query.Lang == "" {
+			return Query{}, fmt.Errorf("sort=lang_bytes requires lang to be set")
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return Query{}, fmt.Errorf("invalid limit %q", v)
+		}
+		query.Limit = n
+	}
+
+	return query, nil
+}
+
+// firstNonEmpty returns the first of values that isn't the empty string.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Predicate reports whether repo should be kept in the result set.
+type Predicate func(providers.Repository) bool
+
+// predicates builds the Predicate pipeline implied by q; every predicate
+// must pass for a repository to be kept.
+func (q Query) predicates() []Predicate {
+	var preds []Predicate
+
+	if q.Lang != "" {
+		preds = append(preds, func(repo providers.Repository) bool {
+			_, ok := repo.Languages[q.Lang]
+			return ok
+		})
+	}
+	if q.Owner != "" {
+		preds = append(preds, func(repo providers.Repository) bool {
+			return repo.Owner == q.Owner
+		})
+	}
+	if q.NameRegex != nil {
+		preds = append(preds, func(repo providers.Repository) bool {
+			return q.NameRegex.MatchString(repo.RepoName)
+		})
+	}
+	if q.MinLanguages > 0 {
+		preds = append(preds, func(repo providers.Repository) bool {
+			return len(repo.Languages) >= q.MinLanguages
+		})
+	}
+	if q.MinBytes > 0 {
+		preds = append(preds, func(repo providers.Repository) bool {
+			return totalBytes(repo) >= q.MinBytes
+		})
+	}
+	return preds
+}
+
+// matches reports whether repo satisfies every predicate in q.
+func (q Query) matches(repo providers.Repository) bool {
+	for _, pred := range q.predicates() {
+		if !pred(repo) {
+			return false
+		}
+	}
+	return true
+}
+
+// apply filters, sorts, and limits repos according to q.
+func (q Query) apply(repos []providers.Repository) []providers.Repository {
+	filtered := make([]providers.Repository, 0, len(repos))
+	for _, repo := range repos {
+		if q.matches(repo) {
+			filtered = append(filtered, repo)
+		}
+	}
+
+	if q.SortBy != "" {
+		sort.Slice(filtered, func(i, j int) bool {
+			vi, vj := q.sortValue(filtered[i]), q.sortValue(filtered[j])
+			if q.SortDesc {
+				return vi > vj
+			}
+			return vi < vj
+		})
+	}
+
+	if q.Limit > 0 && q.Limit < len(filtered) {
+		filtered = filtered[:q.Limit]
+	}
+	return filtered
+}
+
+// sortValue returns the value repo is ranked on for q.SortBy.
+func (q Query) sortValue(repo providers.Repository) int {
+	if q.SortBy == "lang_bytes" {
+		return repo.Languages[q.Lang].Bytes
+	}
+	return totalBytes(repo)
+}
+
+// totalBytes sums the byte count of every language in repo.
+func totalBytes(repo providers.Repository) int {
+	total := 0
+	for _, detail := range repo.Languages {
+		total += detail.Bytes
+	}
+	return total
+}
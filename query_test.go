@@ -0,0 +1,138 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/Scalingo/sclng-backend-test-v1/providers"
+)
+
+func repo(owner, name string, languages providers.Language) providers.Repository {
+	return providers.Repository{
+		FullName:  owner + "/" + name,
+		Owner:     owner,
+		RepoName:  name,
+		Languages: languages,
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	cases := []struct {
+		name    string
+		params  map[string]string
+		query   string
+		wantErr bool
+	}{
+		{name: "empty is valid"},
+		{name: "lang and owner from path params", params: map[string]string{"lang": "go", "owner": "acme"}},
+		{name: "valid name regex", query: "name=^api-"},
+		{name: "invalid name regex", query: "name=(", wantErr: true},
+		{name: "valid min_bytes", query: "min_bytes=10"},
+		{name: "negative min_bytes", query: "min_bytes=-1", wantErr: true},
+		{name: "non-numeric min_bytes", query: "min_bytes=abc", wantErr: true},
+		{name: "negative min_languages", query: "min_languages=-1", wantErr: true},
+		{name: "valid sort", query: "sort=-bytes_total"},
+		{name: "invalid sort value", query: "sort=stars", wantErr: true},
+		{name: "sort=lang_bytes without lang", query: "sort=lang_bytes", wantErr: true},
+		{name: "sort=lang_bytes with lang", params: map[string]string{"lang": "go"}, query: "sort=lang_bytes"},
+		{name: "negative limit", query: "limit=-1", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &http.Request{URL: &url.URL{RawQuery: tc.query}}
+			_, err := parseQuery(r, tc.params)
+			if tc.wantErr && err == nil {
+				t.Fatalf("expected an error, got none")
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestQueryApply_Filters(t *testing.T) {
+	repos := []providers.Repository{
+		repo("acme", "web", providers.Language{"Go": {Bytes: 100}, "JS": {Bytes: 50}}),
+		repo("acme", "cli", providers.Language{"Go": {Bytes: 10}}),
+		repo("other", "lib", providers.Language{"Rust": {Bytes: 500}}),
+	}
+
+	cases := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{name: "no filters keeps everything", query: Query{}, want: []string{"acme/web", "acme/cli", "other/lib"}},
+		{name: "lang filter", query: Query{Lang: "Go"}, want: []string{"acme/web", "acme/cli"}},
+		{name: "owner filter", query: Query{Owner: "acme"}, want: []string{"acme/web", "acme/cli"}},
+		{name: "min_bytes filter", query: Query{MinBytes: 150}, want: []string{"acme/web", "other/lib"}},
+		{name: "min_languages filter", query: Query{MinLanguages: 2}, want: []string{"acme/web"}},
+		{name: "combined filters", query: Query{Lang: "Go", MinBytes: 50}, want: []string{"acme/web"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.query.apply(repos)
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %d repos, want %d (%v)", len(got), len(tc.want), got)
+			}
+			for i, name := range tc.want {
+				if got[i].FullName != name {
+					t.Fatalf("repo %d = %q, want %q", i, got[i].FullName, name)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryApply_SortAndLimit(t *testing.T) {
+	repos := []providers.Repository{
+		repo("acme", "small", providers.Language{"Go": {Bytes: 10}}),
+		repo("acme", "big", providers.Language{"Go": {Bytes: 100}}),
+		repo("acme", "medium", providers.Language{"Go": {Bytes: 50}}),
+	}
+
+	t.Run("ascending by bytes_total", func(t *testing.T) {
+		got := Query{SortBy: "bytes_total"}.apply(repos)
+		want := []string{"acme/small", "acme/medium", "acme/big"}
+		for i, name := range want {
+			if got[i].FullName != name {
+				t.Fatalf("repo %d = %q, want %q", i, got[i].FullName, name)
+			}
+		}
+	})
+
+	t.Run("descending by bytes_total", func(t *testing.T) {
+		got := Query{SortBy: "bytes_total", SortDesc: true}.apply(repos)
+		want := []string{"acme/big", "acme/medium", "acme/small"}
+		for i, name := range want {
+			if got[i].FullName != name {
+				t.Fatalf("repo %d = %q, want %q", i, got[i].FullName, name)
+			}
+		}
+	})
+
+	t.Run("limit truncates the sorted set", func(t *testing.T) {
+		got := Query{SortBy: "bytes_total", SortDesc: true, Limit: 2}.apply(repos)
+		if len(got) != 2 {
+			t.Fatalf("got %d repos, want 2", len(got))
+		}
+		if got[0].FullName != "acme/big" || got[1].FullName != "acme/medium" {
+			t.Fatalf("unexpected order after limit: %v", got)
+		}
+	})
+
+	t.Run("sort by lang_bytes uses only the requested language", func(t *testing.T) {
+		langRepos := []providers.Repository{
+			repo("acme", "a", providers.Language{"Go": {Bytes: 5}, "JS": {Bytes: 900}}),
+			repo("acme", "b", providers.Language{"Go": {Bytes: 20}}),
+		}
+		got := Query{Lang: "Go", SortBy: "lang_bytes", SortDesc: true}.apply(langRepos)
+		if got[0].FullName != "acme/b" {
+			t.Fatalf("expected acme/b (20 Go bytes) first, got %v", got)
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Scalingo/sclng-backend-test-v1/providers"
+)
+
+// fakeProvider is a minimal providers.RepoProvider for exercising
+// repositoriesHandler's fan-out without any real network calls.
+type fakeProvider struct {
+	name      string
+	repos     []providers.Repository
+	listErr   error
+	languages func(repo providers.Repository) (providers.Language, error)
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) ListRepositories(ctx context.Context, opts providers.ListOptions) ([]providers.Repository, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	return f.repos, nil
+}
+
+func (f *fakeProvider) FetchLanguages(ctx context.Context, repo providers.Repository) (providers.Language, error) {
+	if f.languages != nil {
+		return f.languages(repo)
+	}
+	return providers.Language{}, nil
+}
+
+func doRepositoriesRequest(t *testing.T, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	rec := httptest.NewRecorder()
+	if err := repositoriesHandler(rec, req, map[string]string{}); err != nil {
+		t.Fatalf("repositoriesHandler returned error: %v", err)
+	}
+	return rec
+}
+
+func TestRepositoriesHandler_MergesEveryProvider(t *testing.T) {
+	origMounted := mountedProviders
+	defer func() { mountedProviders = origMounted }()
+
+	mountedProviders = map[string]providers.RepoProvider{
+		"a": &fakeProvider{name: "a", repos: []providers.Repository{{Source: "a", FullName: "a/one"}}},
+		"b": &fakeProvider{name: "b", repos: []providers.Repository{{Source: "b", FullName: "b/one"}}},
+	}
+
+	rec := doRepositoriesRequest(t, "/repos")
+
+	var resp RepositoriesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Repositories) != 2 {
+		t.Fatalf("got %d repositories, want 2 (%v)", len(resp.Repositories), resp.Repositories)
+	}
+}
+
+func TestRepositoriesHandler_LanguageFetchFailureIsSoft(t *testing.T) {
+	origMounted := mountedProviders
+	defer func() { mountedProviders = origMounted }()
+
+	mountedProviders = map[string]providers.RepoProvider{
+		"a": &fakeProvider{
+			name: "a",
+			repos: []providers.Repository{
+				{Source: "a", FullName: "a/ok"},
+				{Source: "a", FullName: "a/broken"},
+			},
+			languages: func(repo providers.Repository) (providers.Language, error) {
+				if repo.FullName == "a/broken" {
+					return nil, errors.New("boom")
+				}
+				return providers.Language{"Go": {Bytes: 1}}, nil
+			},
+		},
+	}
+
+	rec := doRepositoriesRequest(t, "/repos")
+
+	var resp RepositoriesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Repositories) != 2 {
+		t.Fatalf("got %d repositories, want 2 (%v)", len(resp.Repositories), resp.Repositories)
+	}
+
+	var broken, ok *providers.Repository
+	for i := range resp.Repositories {
+		switch resp.Repositories[i].FullName {
+		case "a/broken":
+			broken = &resp.Repositories[i]
+		case "a/ok":
+			ok = &resp.Repositories[i]
+		}
+	}
+	if broken == nil || broken.Error == "" {
+		t.Fatalf("expected a/broken to carry a non-empty Error, got %+v", broken)
+	}
+	if ok == nil || ok.Error != "" {
+		t.Fatalf("expected a/ok to have no Error, got %+v", ok)
+	}
+}
+
+func TestRepositoriesHandler_ListFailureAbortsWithError(t *testing.T) {
+	origMounted := mountedProviders
+	defer func() { mountedProviders = origMounted }()
+
+	mountedProviders = map[string]providers.RepoProvider{
+		"broken": &fakeProvider{name: "broken", listErr: errors.New("upstream down")},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/repos", nil)
+	rec := httptest.NewRecorder()
+	err := repositoriesHandler(rec, req, map[string]string{})
+	if err == nil {
+		t.Fatal("expected repositoriesHandler to return the listing error, got nil")
+	}
+}
+
+func TestRepositoriesHandler_RespectsMaxConcurrency(t *testing.T) {
+	origMounted := mountedProviders
+	origMaxConcurrency := defaultMaxConcurrency
+	defer func() {
+		mountedProviders = origMounted
+		defaultMaxConcurrency = origMaxConcurrency
+	}()
+	defaultMaxConcurrency = 2
+
+	const repoCount = 20
+	repos := make([]providers.Repository, 0, repoCount)
+	for i := 0; i < repoCount; i++ {
+		repos = append(repos, providers.Repository{Source: "a", FullName: "a/repo"})
+	}
+
+	var current, max int64
+	mountedProviders = map[string]providers.RepoProvider{
+		"a": &fakeProvider{
+			name:  "a",
+			repos: repos,
+			languages: func(repo providers.Repository) (providers.Language, error) {
+				n := atomic.AddInt64(&current, 1)
+				for {
+					m := atomic.LoadInt64(&max)
+					if n <= m || atomic.CompareAndSwapInt64(&max, m, n) {
+						break
+					}
+				}
+				atomic.AddInt64(&current, -1)
+				return providers.Language{}, nil
+			},
+		},
+	}
+
+	doRepositoriesRequest(t, "/repos")
+
+	if got := atomic.LoadInt64(&max); got > int64(defaultMaxConcurrency) {
+		t.Fatalf("observed %d concurrent language fetches, want at most %d", got, defaultMaxConcurrency)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// newTracerProviderFromEnv builds and registers the *sdktrace.TracerProvider
+// selected by the TRACING_EXPORTER env var, so the spans every RepoProvider
+// already creates (see providers.tracer) are actually collected somewhere
+// instead of running against the default no-op provider. Returns a shutdown
+// func to flush pending spans on exit; it is a no-op when TRACING_EXPORTER
+// is unset.
+func newTracerProviderFromEnv(log logrus.FieldLogger) (shutdown func(context.Context) error, err error) {
+	switch os.Getenv("TRACING_EXPORTER") {
+	case "stdout":
+		log.Info("Using stdout tracing exporter")
+		exporter, err := stdouttrace.New()
+		if err != nil {
+			return nil, err
+		}
+		tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+		otel.SetTracerProvider(tp)
+		return tp.Shutdown, nil
+	default:
+		log.Info("Tracing disabled (set TRACING_EXPORTER=stdout to enable)")
+		return func(context.Context) error { return nil }, nil
+	}
+}